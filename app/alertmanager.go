@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AlertmanagerWebhook은 Prometheus Alertmanager가 webhook receiver로 보내는
+// 요청 본문의 envelope 구조입니다.
+// https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type AlertmanagerWebhook struct {
+	Receiver          string              `json:"receiver"`
+	Status            string              `json:"status"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+}
+
+// AlertmanagerAlert는 webhook envelope 안의 개별 알림입니다.
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"` // firing, resolved
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// handleAlertmanagerWebhook은 Alertmanager의 webhook 알림을 받아 각 알림을
+// 내부 AnalysisResult로 변환합니다. labels.instance를 DeviceID로 사용해,
+// 엣지에서 올라온 로그와 중앙 알림 체계를 같은 DeviceID로 맞춥니다.
+func handleAlertmanagerWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook AlertmanagerWebhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]AnalysisResult, 0, len(webhook.Alerts))
+	for _, alert := range webhook.Alerts {
+		results = append(results, alertToAnalysisResult(alert))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// alertToAnalysisResult는 Alertmanager의 개별 알림을 AnalysisResult로 옮깁니다.
+func alertToAnalysisResult(alert AlertmanagerAlert) AnalysisResult {
+	result := AnalysisResult{
+		Status:   "OK",
+		Alerts:   []string{},
+		DeviceID: alert.Labels["instance"],
+	}
+
+	if alert.Status == "firing" {
+		result.Status = "ALERT"
+		summary := alert.Annotations["summary"]
+		if summary == "" {
+			summary = alert.Annotations["description"]
+		}
+		if summary == "" {
+			summary = fmt.Sprintf("alert %s firing", alert.Fingerprint)
+		}
+		result.Alerts = append(result.Alerts, summary)
+
+		recordAlertmanagerAlert(alert.Labels["severity"])
+	}
+
+	return result
+}
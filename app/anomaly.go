@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	spinkv "github.com/fermyon/spin/sdk/go/v2/kv"
+	spinvariables "github.com/fermyon/spin/sdk/go/v2/variables"
+)
+
+const (
+	ewmaAlpha       = 0.2              // EWMA 평활 계수 α
+	ewmaMinSamples  = 30               // 이상탐지를 시작하기 전 필요한 최소 샘플 수
+	anomalyDefaultK = 3.0              // z-score 임계값 k의 기본값
+	anomalyCooldown = 60 * time.Second // 같은 지표/디바이스에 대한 재알림 억제 시간
+)
+
+// metricBaseline은 디바이스별 한 지표(응답시간/온도)의 EWMA 기반 정상 구간
+// 추정치입니다. KV store에 JSON으로 저장되어 요청 간에 유지됩니다.
+type metricBaseline struct {
+	Mean          float64   `json:"mean"`
+	Variance      float64   `json:"variance"`
+	Samples       int       `json:"samples"`
+	LastAnomalyAt time.Time `json:"last_anomaly_at,omitempty"`
+}
+
+// updateAndCheck는 새 관측값 x로 EWMA 기준선을 갱신하고, 표본이 충분히
+// 쌓인 뒤 |x-μ| > k·σ를 벗어나면 ANOMALY 알림 메시지를 반환합니다(쿨다운
+// 중인 재알림은 억제). 갱신된 기준선은 호출자가 저장해야 합니다.
+func (b metricBaseline) updateAndCheck(x, k float64, now time.Time) (metricBaseline, string) {
+	if b.Samples == 0 {
+		// 첫 샘플은 그대로 기준값으로 삼는다.
+		b.Mean = x
+		b.Variance = 0
+		b.Samples = 1
+		return b, ""
+	}
+
+	prevMean := b.Mean
+	b.Mean = ewmaAlpha*x + (1-ewmaAlpha)*prevMean
+	b.Variance = (1 - ewmaAlpha) * (b.Variance + ewmaAlpha*math.Pow(x-prevMean, 2))
+	b.Samples++
+
+	if b.Samples < ewmaMinSamples {
+		return b, ""
+	}
+
+	stddev := math.Sqrt(b.Variance)
+	if stddev == 0 {
+		return b, ""
+	}
+
+	z := (x - b.Mean) / stddev
+	if math.Abs(z) <= k {
+		return b, ""
+	}
+
+	if !b.LastAnomalyAt.IsZero() && now.Sub(b.LastAnomalyAt) < anomalyCooldown {
+		return b, ""
+	}
+
+	b.LastAnomalyAt = now
+	return b, fmt.Sprintf("ANOMALY: value %.2f deviates z=%.2f from baseline (mean=%.2f, k=%.1f)", x, z, b.Mean, k)
+}
+
+// AnomalyDetector는 Spin key-value store에 디바이스별 EWMA 기준선을 보관하며
+// 정적 임계값만으로는 잡히지 않는, 천천히 드리프트하는 디바이스를 탐지합니다.
+//
+// checkAndUpdate는 읽고-고치고-쓰기 방식으로 기준선을 갱신하는데, spinkv.Store는
+// CAS/트랜잭션을 제공하지 않으므로 같은 deviceID/metric에 대한 두 요청이
+// 겹치면 한쪽의 Set이 다른 쪽의 갱신을 덮어써 mean/variance/samples가 어긋날
+// 수 있다. 실제 동시 트래픽 아래에서는 이 경합이 기준선을 오염시킬 수 있다는
+// 점을 알려진 한계로 남겨둔다 — 필요해지면 호출자가 deviceID별로 직렬화하거나
+// KV store에 CAS가 추가되어야 한다.
+type AnomalyDetector struct{}
+
+var anomalyDetector AnomalyDetector
+
+// checkAndUpdate는 deviceID/metric 조합의 기준선을 불러와 x로 갱신하고 다시
+// 저장한 뒤, ANOMALY 알림 메시지(없으면 빈 문자열)를 반환합니다.
+func (AnomalyDetector) checkAndUpdate(deviceID, metric string, x, k float64, now time.Time) (string, error) {
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		return "", fmt.Errorf("anomaly store: %w", err)
+	}
+	defer store.Close()
+
+	key := anomalyKey(deviceID, metric)
+	var baseline metricBaseline
+	if raw, err := store.Get(key); err == nil {
+		if err := json.Unmarshal(raw, &baseline); err != nil {
+			return "", fmt.Errorf("anomaly store: corrupt baseline for %s/%s: %w", deviceID, metric, err)
+		}
+	}
+
+	updated, alert := baseline.updateAndCheck(x, k, now)
+
+	raw, err := json.Marshal(updated)
+	if err != nil {
+		return "", err
+	}
+	if err := store.Set(key, raw); err != nil {
+		return "", err
+	}
+
+	return alert, nil
+}
+
+func anomalyKey(deviceID, metric string) string {
+	return fmt.Sprintf("anomaly:%s:%s", deviceID, metric)
+}
+
+// anomalyK는 spin.toml의 anomaly_k 변수로 k를 설정할 수 있게 합니다. 값이
+// 없거나 숫자로 해석할 수 없으면 기본값(3.0)을 씁니다.
+func anomalyK() float64 {
+	raw, err := spinvariables.Get("anomaly_k")
+	if err != nil || raw == "" {
+		return anomalyDefaultK
+	}
+	k, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return anomalyDefaultK
+	}
+	return k
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBatchLineBytes는 /batch 스트림에서 한 줄(LogEntry 하나)에 허용하는
+// 최대 바이트 수입니다. 줄 단위로만 버퍼링하므로 업로드 전체 크기와 무관하게
+// 메모리 사용량이 이 값으로 제한됩니다.
+const maxBatchLineBytes = 1 << 20 // 1 MiB
+
+// errLineTooLong은 readBatchLine이 줄 하나가 maxBatchLineBytes를 넘어설 때
+// 반환합니다. 해당 줄의 나머지 바이트는 다음 개행까지 버려지고(resync),
+// 이후 줄은 정상적으로 계속 처리됩니다.
+var errLineTooLong = errors.New("line exceeds maximum length")
+
+// batchLineError는 /batch 스트림에서 파싱에 실패한 줄을 나타냅니다. 한 줄의
+// 에러는 스트림 전체를 중단시키지 않습니다.
+type batchLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// batchSummary는 /batch 스트림의 마지막에 덧붙는 trailer입니다. Truncated가
+// 비어있지 않으면 본문을 더 이상 읽을 수 없는 오류(예: 연결 끊김)로 스캔이
+// 중간에 끊겨 그 이후 항목이 스트림에 반영되지 않았다는 뜻입니다. 한 줄이
+// maxBatchLineBytes를 넘는 경우는 여기 해당하지 않습니다 — 그 줄만
+// batchLineError로 보고하고 다음 줄부터 계속 처리합니다.
+type batchSummary struct {
+	Summary struct {
+		OK        int    `json:"ok"`
+		Alert     int    `json:"alert"`
+		Errors    int    `json:"errors"`
+		Truncated string `json:"truncated,omitempty"`
+	} `json:"summary"`
+}
+
+// handleBatch는 application/x-ndjson으로 올라온 LogEntry를 한 줄씩 분석하여
+// AnalysisResult를 NDJSON으로 스트리밍합니다. 연결 끊김 이후의 재전송(backfill)
+// 시나리오를 위해 ?since=<RFC3339>로 그보다 이전 타임스탬프의 항목을 건너뛸 수
+// 있습니다.
+func handleBatch(w http.ResponseWriter, r *http.Request) {
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid since: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	reader := bufio.NewReaderSize(r.Body, 64*1024)
+
+	var summary batchSummary
+	for lineNo := 1; ; lineNo++ {
+		line, err := readBatchLine(reader)
+
+		if err == errLineTooLong {
+			enc.Encode(batchLineError{Line: lineNo, Error: fmt.Sprintf("line exceeds maximum length of %d bytes", maxBatchLineBytes)})
+			summary.Summary.Errors++
+			if flusher != nil {
+				flusher.Flush()
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			// 줄 경계를 찾을 수 없는 본문 읽기 오류 — 다음 줄로 재동기화할
+			// 방법이 없으므로 여기서만 스트림을 끊는다.
+			summary.Summary.Truncated = err.Error()
+			break
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" {
+			var entry LogEntry
+			if unmarshalErr := json.Unmarshal([]byte(trimmed), &entry); unmarshalErr != nil {
+				enc.Encode(batchLineError{Line: lineNo, Error: unmarshalErr.Error()})
+				summary.Summary.Errors++
+				if flusher != nil {
+					flusher.Flush()
+				}
+			} else if since.IsZero() || !entry.Timestamp.Before(since) {
+				result, alertKinds := analyzeLog(entry)
+				recordAnalysis(result, entry, alertKinds)
+				if result.Status == "ALERT" {
+					summary.Summary.Alert++
+				} else {
+					summary.Summary.OK++
+				}
+
+				enc.Encode(result)
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	enc.Encode(summary)
+}
+
+// readBatchLine은 reader에서 개행으로 끝나는 한 줄을 읽습니다. 줄이
+// maxBatchLineBytes를 넘으면 errLineTooLong을 반환하기 전에 다음 개행까지
+// 남은 바이트를 모두 읽어 버려서, 호출자가 바로 다음 줄부터 이어서 읽을 수
+// 있게 재동기화합니다(스트림의 나머지 줄을 잃지 않기 위함). 스트림이
+// 개행 없이 끝나면 마지막 조각과 io.EOF를 함께 반환합니다.
+func readBatchLine(r *bufio.Reader) (string, error) {
+	var buf []byte
+	tooLong := false
+
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(chunk) > 0 && !tooLong {
+			if len(buf)+len(chunk) > maxBatchLineBytes {
+				tooLong = true
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+
+		switch err {
+		case nil:
+			if tooLong {
+				return "", errLineTooLong
+			}
+			return strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r"), nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if tooLong {
+				return "", errLineTooLong
+			}
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+			return strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r"), io.EOF
+		default:
+			return "", err
+		}
+	}
+}
+
+// parseSince는 ?since= 쿼리 파라미터를 RFC3339 시각으로 해석합니다. 빈
+// 문자열이면 필터를 적용하지 않습니다.
+func parseSince(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -4,88 +4,161 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	spinhttp "github.com/fermyon/spin/sdk/go/v2/http"
 )
 
 // LogEntry는 IoT 센서에서 전송되는 로그 구조체입니다.
 type LogEntry struct {
-	Level        string  `json:"level"`         // 로그 레벨: INFO, WARN, ERROR
-	ResponseTime int     `json:"response_time"` // 응답 시간 (ms)
-	DeviceID     string  `json:"device_id"`     // 디바이스 식별자
-	Temperature  float64 `json:"temperature"`   // 온도 센서 값 (선택)
-	Message      string  `json:"message"`       // 로그 메시지
+	Level        string    `json:"level"`               // 로그 레벨: INFO, WARN, ERROR
+	ResponseTime int       `json:"response_time"`       // 응답 시간 (ms)
+	DeviceID     string    `json:"device_id"`           // 디바이스 식별자
+	Temperature  float64   `json:"temperature"`         // 온도 센서 값 (선택)
+	Humidity     *float64  `json:"humidity,omitempty"`  // 습도 센서 값 (%RH, 선택, WBGT 계산용)
+	Message      string    `json:"message"`             // 로그 메시지
+	Timestamp    time.Time `json:"timestamp,omitempty"` // 로그 발생 시각 (선택, /batch의 since 필터에 사용)
 }
 
 // AnalysisResult는 로그 분석 결과를 담는 구조체입니다.
 type AnalysisResult struct {
-	Status   string   `json:"status"`   // OK, ALERT
-	Alerts   []string `json:"alerts"`   // 발생한 알림 목록
+	Status   string   `json:"status"` // OK, ALERT
+	Alerts   []string `json:"alerts"` // 발생한 알림 목록
 	DeviceID string   `json:"device_id"`
+	WBGT     *float64 `json:"wbgt,omitempty"` // 계산된 WBGT 지수 (°C, Humidity가 있을 때만)
 }
 
 // 임계값 상수 정의
 const (
-	ResponseTimeThreshold = 2000  // 응답 시간 임계값 (ms)
-	TemperatureThreshold  = 80.0  // 온도 임계값 (°C)
+	ResponseTimeThreshold = 2000 // 응답 시간 임계값 (ms)
+	TemperatureThreshold  = 80.0 // 온도 임계값 (°C)
 )
 
 func init() {
-	spinhttp.Handle(func(w http.ResponseWriter, r *http.Request) {
-		// POST 요청만 처리
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	spinhttp.Handle(router)
+}
 
-		// 요청 본문 파싱
-		var log LogEntry
-		if err := json.NewDecoder(r.Body).Decode(&log); err != nil {
-			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
-			return
-		}
+// router는 경로/메서드에 따라 요청을 각 핸들러로 분배합니다.
+func router(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/metrics" && r.Method == http.MethodGet:
+		handleMetrics(w, r)
+	case r.URL.Path == "/alertmanager" && r.Method == http.MethodPost:
+		handleAlertmanagerWebhook(w, r)
+	case r.URL.Path == "/batch" && r.Method == http.MethodPost:
+		handleBatch(w, r)
+	case r.URL.Path == "/policy" && r.Method == http.MethodGet:
+		handlePolicyGet(w, r)
+	case strings.HasPrefix(r.URL.Path, "/policy/") && r.Method == http.MethodPut:
+		handlePolicyPut(w, r, strings.TrimPrefix(r.URL.Path, "/policy/"))
+	case r.Method == http.MethodPost:
+		handleAnalyze(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAnalyze는 IoT 디바이스가 보낸 단건 로그를 분석합니다.
+func handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	// 요청 본문 파싱
+	var log LogEntry
+	if err := json.NewDecoder(r.Body).Decode(&log); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
 
-		// 로그 분석 수행
-		result := analyzeLog(log)
+	// 로그 분석 수행
+	result, alertKinds := analyzeLog(log)
+	recordAnalysis(result, log, alertKinds)
 
-		// 알림이 있으면 콘솔에 출력 (실제 환경에서는 SNS로 전송)
-		if len(result.Alerts) > 0 {
-			for _, alert := range result.Alerts {
-				fmt.Printf("[ALERT] Device: %s - %s\n", result.DeviceID, alert)
-			}
+	// 알림이 있으면 구성된 Notifier(Slack/DingTalk/범용 webhook 등)로 전달
+	if len(result.Alerts) > 0 {
+		if err := notifier.Notify(r.Context(), result); err != nil {
+			fmt.Fprintf(os.Stderr, "notify failed: %v\n", err)
 		}
+	}
 
-		// 응답 반환
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
-	})
+	// 응답 반환
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
 }
 
-// analyzeLog는 로그를 분석하여 이상 징후를 탐지합니다.
-func analyzeLog(log LogEntry) AnalysisResult {
+// analyzeLog는 로그를 분석하여 이상 징후를 탐지합니다. 두 번째 반환값은
+// 발생한 알림을 종류(kind)별로 센 것으로, result.Alerts에 몇 개가 쌓였는지와
+// 무관하게 recordAnalysis가 alerts_total을 실제로 발생한 알림 종류별로
+// 레이블링할 수 있도록 analyzeLog가 직접 집계해 넘긴다.
+func analyzeLog(log LogEntry) (AnalysisResult, map[string]int) {
 	result := AnalysisResult{
 		Status:   "OK",
 		Alerts:   []string{},
 		DeviceID: log.DeviceID,
 	}
+	alertKinds := map[string]int{}
 
 	// 1. ERROR 레벨 로그 감지
 	if log.Level == "ERROR" {
 		result.Alerts = append(result.Alerts, fmt.Sprintf("Error detected: %s", log.Message))
+		alertKinds["error"]++
+	}
+
+	// 디바이스별 정책(임계값)을 조회. KV store를 쓸 수 없으면 기본값으로 대체.
+	policy, err := policyStore.effectivePolicy(log.DeviceID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy lookup failed, using defaults: %v\n", err)
+		policy = defaultPolicy
 	}
 
 	// 2. 응답 시간 임계값 초과 감지
-	if log.ResponseTime > ResponseTimeThreshold {
-		result.Alerts = append(result.Alerts, 
-			fmt.Sprintf("High response time: %dms (threshold: %dms)", 
-				log.ResponseTime, ResponseTimeThreshold))
+	if log.ResponseTime > policy.ResponseTimeThreshold {
+		result.Alerts = append(result.Alerts,
+			fmt.Sprintf("High response time: %dms (threshold: %dms)",
+				log.ResponseTime, policy.ResponseTimeThreshold))
+		alertKinds["response_time"]++
 	}
 
 	// 3. 온도 임계값 초과 감지
-	if log.Temperature > TemperatureThreshold {
+	if log.Temperature > policy.TemperatureThreshold {
 		result.Alerts = append(result.Alerts,
 			fmt.Sprintf("High temperature: %.1f°C (threshold: %.1f°C)",
-				log.Temperature, TemperatureThreshold))
+				log.Temperature, policy.TemperatureThreshold))
+		alertKinds["temperature"]++
+	}
+
+	// 4. 습도 값이 있으면 WBGT(습구흑구온도, 열지수) 계산 및 등급별 알림
+	if log.Humidity != nil {
+		wbgt, err := computeWBGT(log.Temperature, *log.Humidity)
+		if err != nil {
+			result.Alerts = append(result.Alerts, fmt.Sprintf("WBGT calculation error: %v", err))
+			alertKinds["wbgt_error"]++
+		} else {
+			result.WBGT = &wbgt
+			if alert := wbgtAlert(wbgt); alert != "" {
+				result.Alerts = append(result.Alerts, alert)
+				alertKinds["wbgt"]++
+			}
+		}
+	}
+
+	// 5. 디바이스별 EWMA 기준선 대비 이상탐지 (응답시간/온도)
+	if log.DeviceID != "" {
+		k := anomalyK()
+		now := time.Now()
+
+		if alert, err := anomalyDetector.checkAndUpdate(log.DeviceID, "response_time", float64(log.ResponseTime), k, now); err != nil {
+			fmt.Fprintf(os.Stderr, "anomaly detection failed: %v\n", err)
+		} else if alert != "" {
+			result.Alerts = append(result.Alerts, "response_time "+alert)
+			alertKinds["anomaly_response_time"]++
+		}
+
+		if alert, err := anomalyDetector.checkAndUpdate(log.DeviceID, "temperature", log.Temperature, k, now); err != nil {
+			fmt.Fprintf(os.Stderr, "anomaly detection failed: %v\n", err)
+		} else if alert != "" {
+			result.Alerts = append(result.Alerts, "temperature "+alert)
+			alertKinds["anomaly_temperature"]++
+		}
 	}
 
 	// 알림이 있으면 상태를 ALERT로 변경
@@ -93,7 +166,7 @@ func analyzeLog(log LogEntry) AnalysisResult {
 		result.Status = "ALERT"
 	}
 
-	return result
+	return result, alertKinds
 }
 
 func main() {}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	spinkv "github.com/fermyon/spin/sdk/go/v2/kv"
+)
+
+// responseTimeBuckets는 response_time 히스토그램의 버킷 상한(ms)입니다.
+var responseTimeBuckets = []float64{50, 100, 250, 500, 1000, 2000, 5000}
+
+// metricsStateKey는 KV store에 누적 지표를 저장하는 키입니다. Spin 컴포넌트는
+// 요청마다 새 인스턴스로 기동되므로, PolicyStore/AnomalyDetector와 같은 방식으로
+// KV store에 저장해야 요청 간에 값이 유지됩니다.
+const metricsStateKey = "metrics:state"
+
+// metricsState는 /metrics에서 노출하는 Prometheus 지표의 누적 상태입니다.
+// alertsTotal은 수집된 로그에서 analyzeLog가 찾아낸 알림을 종류(kind: error,
+// response_time, temperature, wbgt, anomaly_response_time, ...)별로,
+// alertmanagerAlertsTotal은 Alertmanager webhook(severity)에서 온 알림을
+// 센다 — 두 소스는 레이블 체계가 달라 같은 카운터에 섞으면 집계가
+// 무의미해지므로 분리한다.
+//
+// KV store에는 CAS/트랜잭션이 없어(spinkv.Store는 Get/Set/Delete만 제공)
+// loadMetricsState/saveMetricsState의 읽고-고치고-쓰기 구간에서 동시 요청이
+// 겹치면 한쪽의 갱신이 유실될 수 있다. 카운터이므로 치명적이지는 않지만,
+// 경합이 잦으면 집계가 실제보다 낮게 나올 수 있다는 점을 알려진 한계로
+// 남겨둔다.
+type metricsState struct {
+	AlertsTotal             map[string]int     `json:"alerts_total"`
+	AlertmanagerAlertsTotal map[string]int     `json:"alertmanager_alerts_total"`
+	ResponseTimeCount       int                `json:"response_time_count"`
+	ResponseTimeSum         float64            `json:"response_time_sum"`
+	ResponseTimeBucket      []int              `json:"response_time_bucket"` // responseTimeBuckets와 같은 길이
+	Temperature             map[string]float64 `json:"temperature"`          // device_id -> 마지막 온도 값
+}
+
+// loadMetricsState는 KV store에서 누적 지표를 불러옵니다. 값이 없으면
+// 빈 상태로 시작합니다.
+func loadMetricsState(store *spinkv.Store) (metricsState, error) {
+	state := metricsState{
+		AlertsTotal:             map[string]int{},
+		AlertmanagerAlertsTotal: map[string]int{},
+		ResponseTimeBucket:      make([]int, len(responseTimeBuckets)),
+		Temperature:             map[string]float64{},
+	}
+
+	raw, err := store.Get(metricsStateKey)
+	if err != nil {
+		return state, nil
+	}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return state, fmt.Errorf("metrics store: corrupt state: %w", err)
+	}
+
+	if state.AlertsTotal == nil {
+		state.AlertsTotal = map[string]int{}
+	}
+	if state.AlertmanagerAlertsTotal == nil {
+		state.AlertmanagerAlertsTotal = map[string]int{}
+	}
+	if state.Temperature == nil {
+		state.Temperature = map[string]float64{}
+	}
+	if len(state.ResponseTimeBucket) != len(responseTimeBuckets) {
+		state.ResponseTimeBucket = make([]int, len(responseTimeBuckets))
+	}
+
+	return state, nil
+}
+
+// saveMetricsState는 누적 지표를 KV store에 기록합니다.
+func saveMetricsState(store *spinkv.Store, state metricsState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return store.Set(metricsStateKey, raw)
+}
+
+// recordAnalysis는 분석 결과 하나를 메트릭 상태에 반영합니다. alertKinds는
+// analyzeLog가 집계한, 실제로 발생한 알림의 종류별 개수입니다 — 예전에는
+// result.Alerts의 개수만큼 log.Level 하나로 전부 카운트해서, 한 INFO 로그가
+// 온도/WBGT/이상탐지 알림을 여러 개 띄우면 alerts_total{level="INFO"}가
+// 엉뚱하게 부풀려졌다. 알림은 level이 아니라 그것을 일으킨 종류(kind)로
+// 세어야 Alertmanager 규칙이 이 지표를 근거로 동작할 수 있다.
+func recordAnalysis(result AnalysisResult, log LogEntry, alertKinds map[string]int) {
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+		return
+	}
+	defer store.Close()
+
+	state, err := loadMetricsState(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+		return
+	}
+
+	for kind, n := range alertKinds {
+		state.AlertsTotal[kind] += n
+	}
+
+	state.ResponseTimeCount++
+	state.ResponseTimeSum += float64(log.ResponseTime)
+	for i, le := range responseTimeBuckets {
+		if float64(log.ResponseTime) <= le {
+			state.ResponseTimeBucket[i]++
+		}
+	}
+
+	if log.DeviceID != "" {
+		state.Temperature[log.DeviceID] = log.Temperature
+	}
+
+	if err := saveMetricsState(store, state); err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+	}
+}
+
+// recordAlertmanagerAlert는 Alertmanager webhook에서 들어온 firing 알림 하나를
+// severity별로 센다. recordAnalysis의 kind별 alerts_total과는 별도의 시리즈이다.
+func recordAlertmanagerAlert(severity string) {
+	if severity == "" {
+		severity = "unknown"
+	}
+
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+		return
+	}
+	defer store.Close()
+
+	state, err := loadMetricsState(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+		return
+	}
+
+	state.AlertmanagerAlertsTotal[severity]++
+
+	if err := saveMetricsState(store, state); err != nil {
+		fmt.Fprintf(os.Stderr, "metrics store: %v\n", err)
+	}
+}
+
+// handleMetrics는 Prometheus text exposition format으로 KV store에 누적된
+// 지표를 렌더링합니다.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("metrics store: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer store.Close()
+
+	state, err := loadMetricsState(store)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("metrics store: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "# HELP alerts_total Total number of alerts raised from ingested logs, by alert kind.")
+	fmt.Fprintln(&b, "# TYPE alerts_total counter")
+	writeCounterByLabel(&b, "alerts_total", "kind", state.AlertsTotal)
+
+	fmt.Fprintln(&b, "# HELP alertmanager_alerts_total Total number of firing alerts received from Alertmanager, by severity.")
+	fmt.Fprintln(&b, "# TYPE alertmanager_alerts_total counter")
+	writeCounterByLabel(&b, "alertmanager_alerts_total", "severity", state.AlertmanagerAlertsTotal)
+
+	fmt.Fprintln(&b, "# HELP response_time_milliseconds Observed log response times.")
+	fmt.Fprintln(&b, "# TYPE response_time_milliseconds histogram")
+	for i, le := range responseTimeBuckets {
+		// state.ResponseTimeBucket[i]는 recordAnalysis에서 이미 "값 <= le"인
+		// 관측치의 누적 카운트이므로, 여기서 다시 누적 합을 구하면 안 된다.
+		fmt.Fprintf(&b, "response_time_milliseconds_bucket{le=%q} %d\n", formatBucket(le), state.ResponseTimeBucket[i])
+	}
+	fmt.Fprintf(&b, "response_time_milliseconds_bucket{le=\"+Inf\"} %d\n", state.ResponseTimeCount)
+	fmt.Fprintf(&b, "response_time_milliseconds_sum %v\n", state.ResponseTimeSum)
+	fmt.Fprintf(&b, "response_time_milliseconds_count %d\n", state.ResponseTimeCount)
+
+	fmt.Fprintln(&b, "# HELP temperature_celsius Last reported temperature per device.")
+	fmt.Fprintln(&b, "# TYPE temperature_celsius gauge")
+	devices := make([]string, 0, len(state.Temperature))
+	for device := range state.Temperature {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		fmt.Fprintf(&b, "temperature_celsius{device_id=%q} %v\n", device, state.Temperature[device])
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprint(w, b.String())
+}
+
+// writeCounterByLabel은 label별 카운터 시리즈를 키 정렬 순서로 출력합니다.
+func writeCounterByLabel(b *strings.Builder, metric, label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", metric, label, k, counts[k])
+	}
+}
+
+// formatBucket은 히스토그램 버킷 상한을 Prometheus 관례에 맞춰 문자열로 만듭니다.
+func formatBucket(le float64) string {
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	spinvariables "github.com/fermyon/spin/sdk/go/v2/variables"
+)
+
+// Notifier는 분석 결과를 외부 채널로 전달하는 알림 싱크입니다.
+type Notifier interface {
+	Notify(ctx context.Context, result AnalysisResult) error
+}
+
+// notifier는 init()에서 spin.toml 설정에 따라 구성되는 전역 알림 싱크입니다.
+var notifier Notifier = noopNotifier{}
+
+func init() {
+	notifier = newNotifierFromConfig()
+}
+
+// newNotifierFromConfig는 spin.toml의 notifier_kind/notifier_url/notifier_secret
+// 변수를 읽어 Notifier를 구성합니다. notifier_kind는 콤마로 여러 싱크를 나열할 수
+// 있으며, 둘 이상이면 FanOutNotifier로 묶입니다. 설정이 없거나 인식할 수 없는
+// kind만 있으면 아무 것도 하지 않는 noopNotifier를 반환합니다.
+func newNotifierFromConfig() Notifier {
+	kind, _ := spinvariables.Get("notifier_kind")
+	webhookURL, _ := spinvariables.Get("notifier_url")
+	secret, _ := spinvariables.Get("notifier_secret")
+
+	var sinks []Notifier
+	for _, k := range strings.Split(kind, ",") {
+		switch strings.TrimSpace(k) {
+		case "slack":
+			sinks = append(sinks, &SlackNotifier{WebhookURL: webhookURL})
+		case "dingtalk":
+			sinks = append(sinks, &DingTalkNotifier{WebhookURL: webhookURL, Secret: secret})
+		case "webhook":
+			sinks = append(sinks, &WebhookNotifier{EndpointURL: webhookURL, Secret: secret})
+		}
+	}
+
+	switch len(sinks) {
+	case 0:
+		return noopNotifier{}
+	case 1:
+		return sinks[0]
+	default:
+		return FanOutNotifier(sinks)
+	}
+}
+
+// noopNotifier는 notifier_kind가 설정되지 않았을 때 쓰이는 기본값입니다.
+type noopNotifier struct{}
+
+func (noopNotifier) Notify(ctx context.Context, result AnalysisResult) error { return nil }
+
+// FanOutNotifier는 여러 Notifier에 동시에 알림을 보냅니다. 개별 싱크 실패는
+// stderr로만 남기고 나머지 싱크나 HTTP 응답에는 영향을 주지 않습니다.
+type FanOutNotifier []Notifier
+
+func (f FanOutNotifier) Notify(ctx context.Context, result AnalysisResult) error {
+	var wg sync.WaitGroup
+	for _, n := range f {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, result); err != nil {
+				fmt.Fprintf(os.Stderr, "notifier failed: %v\n", err)
+			}
+		}(n)
+	}
+	wg.Wait()
+	return nil
+}
+
+// formatAlertText는 알림 싱크 전반에서 공유하는 사람이 읽을 메시지 포맷입니다.
+func formatAlertText(result AnalysisResult) string {
+	if len(result.Alerts) == 0 {
+		return fmt.Sprintf("[%s] device %s", result.Status, result.DeviceID)
+	}
+	return fmt.Sprintf("[%s] device %s: %s", result.Status, result.DeviceID, strings.Join(result.Alerts, "; "))
+}
+
+// SlackNotifier는 Slack incoming webhook으로 알림을 보냅니다.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, result AnalysisResult) error {
+	if s.WebhookURL == "" {
+		return fmt.Errorf("slack: notifier_url not configured")
+	}
+
+	body, err := json.Marshal(slackPayload{Text: formatAlertText(result)})
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return postJSON(ctx, s.WebhookURL, body, nil)
+}
+
+// DingTalkNotifier는 DingTalk 그룹 로봇 webhook으로 markdown 메시지를 보냅니다.
+type DingTalkNotifier struct {
+	WebhookURL string
+	Secret     string
+}
+
+type dingTalkPayload struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+}
+
+func (d *DingTalkNotifier) Notify(ctx context.Context, result AnalysisResult) error {
+	if d.WebhookURL == "" {
+		return fmt.Errorf("dingtalk: notifier_url not configured")
+	}
+
+	targetURL := d.WebhookURL
+	if d.Secret != "" {
+		signedURL, err := dingTalkSign(d.WebhookURL, d.Secret, time.Now())
+		if err != nil {
+			return fmt.Errorf("dingtalk: %w", err)
+		}
+		targetURL = signedURL
+	}
+
+	var payload dingTalkPayload
+	payload.MsgType = "markdown"
+	payload.Markdown.Title = fmt.Sprintf("[%s] %s", result.Status, result.DeviceID)
+	payload.Markdown.Text = formatAlertText(result)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("dingtalk: %w", err)
+	}
+	return postJSON(ctx, targetURL, body, nil)
+}
+
+// dingTalkSign은 DingTalk 그룹 로봇의 보안 설정(서명 검증) 방식에 따라
+// timestamp/sign 쿼리 파라미터를 webhook URL에 덧붙입니다.
+// https://open.dingtalk.com/document/robots/customize-robot-security-settings
+func dingTalkSign(webhookURL, secret string, now time.Time) (string, error) {
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	stringToSign := timestamp + "\n" + secret
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", sign)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// WebhookNotifier는 secret을 Authorization 헤더로 실은 bearer 인증 방식의
+// 범용 HTTPS webhook으로 알림을 전달합니다. AWS SNS의 Publish API는 SigV4
+// 서명이 필요해 이 방식으로는 호출할 수 없으므로, SNS 연동이 필요하면 이
+// 싱크 뒤에 SigV4 서명을 대신 해 주는 프록시(API Gateway/Lambda 등)를 두고
+// 그 프록시의 URL을 notifier_url에 설정한다.
+type WebhookNotifier struct {
+	EndpointURL string
+	Secret      string
+}
+
+type webhookMessage struct {
+	DeviceID string   `json:"device_id"`
+	Status   string   `json:"status"`
+	Alerts   []string `json:"alerts"`
+}
+
+func (wh *WebhookNotifier) Notify(ctx context.Context, result AnalysisResult) error {
+	if wh.EndpointURL == "" {
+		return fmt.Errorf("webhook: notifier_url not configured")
+	}
+
+	body, err := json.Marshal(webhookMessage{
+		DeviceID: result.DeviceID,
+		Status:   result.Status,
+		Alerts:   result.Alerts,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	var headers http.Header
+	if wh.Secret != "" {
+		headers = http.Header{"Authorization": []string{"Bearer " + wh.Secret}}
+	}
+	return postJSON(ctx, wh.EndpointURL, body, headers)
+}
+
+// postJSON은 알림 싱크들이 공유하는 JSON POST 헬퍼입니다. Spin SDK가
+// http.DefaultClient를 outbound 허용 목록을 거치는 트랜스포트로 교체해두므로,
+// 표준 net/http 클라이언트를 그대로 사용합니다.
+func postJSON(ctx context.Context, targetURL string, body []byte, headers http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	spinkv "github.com/fermyon/spin/sdk/go/v2/kv"
+)
+
+// Policy는 디바이스에 적용되는 임계값 정책입니다.
+type Policy struct {
+	ResponseTimeThreshold int     `json:"response_time_threshold"`
+	TemperatureThreshold  float64 `json:"temperature_threshold"`
+}
+
+const (
+	policyStoreName       = "default"
+	policyDefaultKey      = "policy:default"
+	policyDeviceKeyPrefix = "policy:device:"
+)
+
+// defaultPolicy는 KV store에 값이 없을 때 쓰이는 기본 정책으로, 기존
+// ResponseTimeThreshold/TemperatureThreshold 상수를 그대로 사용합니다.
+var defaultPolicy = Policy{
+	ResponseTimeThreshold: ResponseTimeThreshold,
+	TemperatureThreshold:  TemperatureThreshold,
+}
+
+// PolicyStore는 Spin key-value store에 기본 정책과 디바이스별 재정의를
+// 보관하여, 재배포 없이 런타임에 임계값을 바꿀 수 있게 합니다.
+type PolicyStore struct{}
+
+// policyStore는 analyzeLog와 /policy 핸들러가 공유하는 전역 스토어입니다.
+var policyStore PolicyStore
+
+// effectivePolicy는 deviceID에 적용할 정책을 반환합니다. deviceID가
+// 비어있으면 기본 정책을, 재정의가 없으면 기본 정책으로 대체된 값을
+// 반환합니다.
+func (PolicyStore) effectivePolicy(deviceID string) (Policy, error) {
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		return defaultPolicy, fmt.Errorf("policy store: %w", err)
+	}
+	defer store.Close()
+
+	policy := defaultPolicy
+	if raw, err := store.Get(policyDefaultKey); err == nil {
+		if err := json.Unmarshal(raw, &policy); err != nil {
+			return defaultPolicy, fmt.Errorf("policy store: corrupt default policy: %w", err)
+		}
+	}
+
+	if deviceID == "" {
+		return policy, nil
+	}
+
+	raw, err := store.Get(policyDeviceKeyPrefix + deviceID)
+	if err != nil {
+		// 재정의가 없으면 기본(혹은 방금 읽은 전역) 정책을 그대로 사용
+		return policy, nil
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return policy, fmt.Errorf("policy store: corrupt policy for device %q: %w", deviceID, err)
+	}
+	return policy, nil
+}
+
+// setDevicePolicy는 특정 디바이스의 정책 재정의를 KV store에 기록합니다.
+func (PolicyStore) setDevicePolicy(deviceID string, policy Policy) error {
+	store, err := spinkv.OpenStore(policyStoreName)
+	if err != nil {
+		return fmt.Errorf("policy store: %w", err)
+	}
+	defer store.Close()
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return store.Set(policyDeviceKeyPrefix+deviceID, raw)
+}
+
+// handlePolicyGet은 GET /policy[?device_id=...]로 유효 정책을 반환합니다.
+// device_id가 없으면 기본 정책을 반환합니다.
+func handlePolicyGet(w http.ResponseWriter, r *http.Request) {
+	deviceID := r.URL.Query().Get("device_id")
+	policy, err := policyStore.effectivePolicy(deviceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// handlePolicyPut은 PUT /policy/{device_id}로 디바이스별 정책을 갱신합니다.
+// 변경 사항은 재배포 없이 다음 요청부터 바로 적용됩니다.
+func handlePolicyPut(w http.ResponseWriter, r *http.Request, deviceID string) {
+	if deviceID == "" {
+		http.Error(w, "device_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var policy Policy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := policyStore.setDevicePolicy(deviceID, policy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
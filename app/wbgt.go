@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// WBGT 등급 경계값 (°C). 실내 작업환경 기준의 약식 등급입니다.
+const (
+	wbgtCaution       = 25.0
+	wbgtWarning       = 28.0
+	wbgtDanger        = 31.0
+	wbgtExtremeDanger = 35.0
+)
+
+// computeWBGT는 실내 약식 공식 WBGT ≈ 0.7·Tw + 0.3·Ta로 WBGT(습구흑구온도)
+// 지수를 계산합니다. 습구온도 Tw는 Stull의 근사식으로 구하며, ta는 °C,
+// rh는 %입니다. Stull의 근사식은 영하 기온이나 0~100% 범위를 벗어난 습도에
+// 대해서는 유효하지 않으므로(범위를 벗어난 rh는 math.Sqrt에 음수를 넘겨
+// NaN을 만들 수 있다) 에러를 반환합니다.
+func computeWBGT(ta, rh float64) (float64, error) {
+	if ta < 0 {
+		return 0, fmt.Errorf("WBGT is undefined for sub-zero temperature: %.1f°C", ta)
+	}
+	if rh < 0 || rh > 100 {
+		return 0, fmt.Errorf("WBGT is undefined for humidity out of range [0, 100]: %.1f%%", rh)
+	}
+
+	tw := ta*math.Atan(0.151977*math.Sqrt(rh+8.313659)) +
+		math.Atan(ta+rh) -
+		math.Atan(rh-1.676331) +
+		0.00391838*math.Pow(rh, 1.5)*math.Atan(0.023101*rh) -
+		4.686035
+
+	return 0.7*tw + 0.3*ta, nil
+}
+
+// wbgtAlert는 WBGT 값에 해당하는 등급 알림 메시지를 만듭니다. 모든 등급
+// 미만이면 빈 문자열을 반환합니다.
+func wbgtAlert(wbgt float64) string {
+	switch {
+	case wbgt >= wbgtExtremeDanger:
+		return fmt.Sprintf("WBGT Extreme Danger: %.1f°C", wbgt)
+	case wbgt >= wbgtDanger:
+		return fmt.Sprintf("WBGT Danger: %.1f°C", wbgt)
+	case wbgt >= wbgtWarning:
+		return fmt.Sprintf("WBGT Warning: %.1f°C", wbgt)
+	case wbgt >= wbgtCaution:
+		return fmt.Sprintf("WBGT Caution: %.1f°C", wbgt)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWbgtAlertGradeBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		wbgt float64
+		want string
+	}{
+		{"below caution", 24.9, ""},
+		{"caution boundary", 25.0, "WBGT Caution: 25.0°C"},
+		{"just below warning", 27.9, "WBGT Caution: 27.9°C"},
+		{"warning boundary", 28.0, "WBGT Warning: 28.0°C"},
+		{"just below danger", 30.9, "WBGT Warning: 30.9°C"},
+		{"danger boundary", 31.0, "WBGT Danger: 31.0°C"},
+		{"just below extreme danger", 34.9, "WBGT Danger: 34.9°C"},
+		{"extreme danger boundary", 35.0, "WBGT Extreme Danger: 35.0°C"},
+		{"well above extreme danger", 40.0, "WBGT Extreme Danger: 40.0°C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wbgtAlert(tt.wbgt); got != tt.want {
+				t.Errorf("wbgtAlert(%v) = %q, want %q", tt.wbgt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeWBGT(t *testing.T) {
+	tests := []struct {
+		name    string
+		ta, rh  float64
+		wantErr bool
+	}{
+		{"dry air", 30.0, 0, false},
+		{"saturated air", 30.0, 100, false},
+		{"typical indoor reading", 28.0, 60, false},
+		{"sub-zero temperature is invalid", -1.0, 50, true},
+		{"negative humidity is invalid", 30.0, -5, true},
+		{"humidity over 100 is invalid", 30.0, 150, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeWBGT(tt.ta, tt.rh)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("computeWBGT(%v, %v) expected an error, got wbgt=%v", tt.ta, tt.rh, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("computeWBGT(%v, %v) unexpected error: %v", tt.ta, tt.rh, err)
+			}
+			if math.IsNaN(got) {
+				t.Fatalf("computeWBGT(%v, %v) = NaN", tt.ta, tt.rh)
+			}
+		})
+	}
+}
+
+func TestAnalyzeLogWithHumidity(t *testing.T) {
+	humidity := 60.0
+	entry := LogEntry{
+		DeviceID:    "device-1",
+		Temperature: 32.0,
+		Humidity:    &humidity,
+	}
+
+	result, _ := analyzeLog(entry)
+
+	if result.WBGT == nil {
+		t.Fatal("expected WBGT to be populated when Humidity is set")
+	}
+	if result.Status != "ALERT" {
+		t.Fatalf("expected ALERT status for a high WBGT reading, got %q", result.Status)
+	}
+}
+
+func TestAnalyzeLogWithoutHumiditySkipsWBGT(t *testing.T) {
+	entry := LogEntry{DeviceID: "device-1", Temperature: 32.0}
+
+	result, _ := analyzeLog(entry)
+
+	if result.WBGT != nil {
+		t.Fatalf("expected WBGT to stay nil without Humidity, got %v", *result.WBGT)
+	}
+}
+
+func TestAnalyzeLogWithSubZeroTemperatureReportsError(t *testing.T) {
+	humidity := 50.0
+	entry := LogEntry{
+		DeviceID:    "device-1",
+		Temperature: -5.0,
+		Humidity:    &humidity,
+	}
+
+	result, _ := analyzeLog(entry)
+
+	if result.WBGT != nil {
+		t.Fatalf("expected no WBGT value on calculation error, got %v", *result.WBGT)
+	}
+	if result.Status != "ALERT" {
+		t.Fatalf("expected calculation error to surface as an ALERT, got %q", result.Status)
+	}
+}